@@ -0,0 +1,262 @@
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// MediaTypeDockerManifestList and MediaTypeImageIndex are the two container
+// formats that can reference multiple platform-specific manifests from a
+// single tag.
+const (
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeImageIndex         = "application/vnd.oci.image.index.v1+json"
+)
+
+// Platform identifies a single os/arch/variant combination used to select
+// manifests out of a multi-arch manifest list or OCI image index.
+type Platform struct {
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+// String returns the canonical "os/arch[/variant]" representation of p.
+func (p Platform) String() string {
+	if p.Variant != "" {
+		return fmt.Sprintf("%s/%s/%s", p.OS, p.Architecture, p.Variant)
+	}
+	return fmt.Sprintf("%s/%s", p.OS, p.Architecture)
+}
+
+// Matches reports whether d was built for p. The variant is only compared
+// when p specifies one, since most manifests omit variant for non-arm platforms.
+func (p Platform) Matches(d PlatformDescriptor) bool {
+	if p.OS != d.OS || p.Architecture != d.Architecture {
+		return false
+	}
+	if p.Variant == "" {
+		return true
+	}
+	return p.Variant == d.Variant
+}
+
+// ParsePlatform parses a "os/arch[/variant]" string as produced by the
+// --platforms flag.
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	switch len(parts) {
+	case 2:
+		return Platform{OS: parts[0], Architecture: parts[1]}, nil
+	case 3:
+		return Platform{OS: parts[0], Architecture: parts[1], Variant: parts[2]}, nil
+	default:
+		return Platform{}, fmt.Errorf("invalid platform %q, expected os/arch[/variant]", s)
+	}
+}
+
+// ParsePlatforms parses every entry of the --platforms flag value.
+func ParsePlatforms(values []string) ([]Platform, error) {
+	platforms := make([]Platform, 0, len(values))
+	for _, v := range values {
+		p, err := ParsePlatform(v)
+		if err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, p)
+	}
+	return platforms, nil
+}
+
+// PlatformDescriptor is the subset of a manifest list / image index child
+// descriptor that identifies the platform it was built for.
+type PlatformDescriptor struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// ManifestDescriptor is one child entry of a manifest list or image index.
+type ManifestDescriptor struct {
+	MediaType   string             `json:"mediaType"`
+	Digest      string             `json:"digest"`
+	Size        int64              `json:"size"`
+	Platform    PlatformDescriptor `json:"platform"`
+	Annotations map[string]string  `json:"annotations,omitempty"`
+}
+
+// ImageIndex is the minimal shape shared by a Docker v2s2 manifest list and
+// an OCI image index - both are handled identically by the selection and
+// reassembly logic below.
+type ImageIndex struct {
+	SchemaVersion int                  `json:"schemaVersion"`
+	MediaType     string               `json:"mediaType"`
+	Manifests     []ManifestDescriptor `json:"manifests"`
+	Annotations   map[string]string    `json:"annotations,omitempty"`
+}
+
+// IsMultiArch reports whether mediaType identifies a manifest list or image
+// index rather than a single-platform manifest.
+func IsMultiArch(mediaType string) bool {
+	return mediaType == MediaTypeDockerManifestList || mediaType == MediaTypeImageIndex
+}
+
+// SelectManifests filters idx down to the children matching platforms. When
+// allPlatforms is true every child is returned regardless of platforms.
+func SelectManifests(idx ImageIndex, platforms []Platform, allPlatforms bool) ([]ManifestDescriptor, error) {
+	if allPlatforms {
+		return idx.Manifests, nil
+	}
+	if len(platforms) == 0 {
+		return nil, fmt.Errorf("multi-arch image index found but no --platforms or --all-platforms was specified")
+	}
+	var selected []ManifestDescriptor
+	for _, p := range platforms {
+		found := false
+		for _, m := range idx.Manifests {
+			if p.Matches(m.Platform) {
+				selected = append(selected, m)
+				found = true
+				break
+			}
+		}
+		if !found {
+			klog.Warningf("platform %s not present in image index, skipping", p)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("none of the requested platforms were found in the image index")
+	}
+	return selected, nil
+}
+
+// RegistryClient is the minimal surface MirrorImageIndex needs from a
+// registry transport to fetch an index, copy one of its child manifests,
+// and push the reassembled index back out, independent of how those
+// operations are actually performed against a given registry.
+type RegistryClient interface {
+	// GetIndex fetches and decodes the manifest list / image index for ref.
+	GetIndex(ctx context.Context, ref string) (ImageIndex, error)
+	// CopyManifest copies a single child manifest (and its config and layers)
+	// identified by digest from src to dst, returning the digest it was
+	// stored under at the destination (normally unchanged).
+	CopyManifest(ctx context.Context, src, dst, digest string) (string, error)
+	// PutIndex stores idx at dst under its own digest and tags it, returning
+	// the resulting index digest.
+	PutIndex(ctx context.Context, dst string, idx ImageIndex) (string, error)
+}
+
+// MirrorImageIndex copies the manifests selected out of src's image index to
+// dst and reassembles an equivalent index at dst that references only the
+// copied children, preserving digests, annotations and platform descriptors.
+func MirrorImageIndex(ctx context.Context, client RegistryClient, src, dst string, platforms []Platform, allPlatforms bool) (string, error) {
+	idx, err := client.GetIndex(ctx, src)
+	if err != nil {
+		return "", fmt.Errorf("fetching image index for %s: %w", src, err)
+	}
+	if !IsMultiArch(idx.MediaType) {
+		return "", fmt.Errorf("%s is not a manifest list or OCI image index (mediaType %s)", src, idx.MediaType)
+	}
+
+	selected, err := SelectManifests(idx, platforms, allPlatforms)
+	if err != nil {
+		return "", err
+	}
+
+	out := ImageIndex{
+		SchemaVersion: idx.SchemaVersion,
+		MediaType:     idx.MediaType,
+		Annotations:   idx.Annotations,
+	}
+	for _, m := range selected {
+		digest, err := client.CopyManifest(ctx, src, dst, m.Digest)
+		if err != nil {
+			return "", fmt.Errorf("copying %s manifest for %s: %w", m.Platform, src, err)
+		}
+		m.Digest = digest
+		out.Manifests = append(out.Manifests, m)
+		klog.Infof("mirrored %s (%s)", m.Platform, digest)
+	}
+
+	indexDigest, err := client.PutIndex(ctx, dst, out)
+	if err != nil {
+		return "", fmt.Errorf("pushing reassembled image index to %s: %w", dst, err)
+	}
+	return indexDigest, nil
+}
+
+// whiteoutPrefix marks a file as deleted in the layer below it, per the OCI
+// image layer filesystem changeset spec.
+const whiteoutPrefix = ".wh."
+
+// ExtractCatalogConfigs walks the layers of the child manifest matching
+// platform (outermost first) and returns the contents of /configs/catalog.json
+// or /configs/catalog.yaml, honoring whiteout files so a later layer's
+// deletion of a file hides it from earlier layers - the same semantics
+// go-containerregistry's mutate package applies when flattening an image.
+func ExtractCatalogConfigs(platform Platform, layers []io.Reader) ([]byte, error) {
+	whiteouts := map[string]bool{}
+	for i := len(layers) - 1; i >= 0; i-- {
+		data, err := readCatalogFromLayer(layers[i], whiteouts)
+		if err != nil {
+			return nil, fmt.Errorf("reading layer %d for platform %s: %w", i, platform, err)
+		}
+		if data != nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("no /configs/catalog.json or catalog.yaml found for platform %s", platform)
+}
+
+// readCatalogFromLayer scans a single gzip-compressed tar layer, recording
+// any whiteout markers it finds and returning the catalog file's contents if
+// present and not already whited out by a later layer.
+func readCatalogFromLayer(layer io.Reader, whiteouts map[string]bool) ([]byte, error) {
+	gzr, err := gzip.NewReader(layer)
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		base := strings.TrimPrefix(hdr.Name, "/")
+		dir, name := splitPath(base)
+		if strings.HasPrefix(name, whiteoutPrefix) {
+			whiteouts[dir+strings.TrimPrefix(name, whiteoutPrefix)] = true
+			continue
+		}
+		if whiteouts[base] {
+			continue
+		}
+		if base == "configs/catalog.json" || base == "configs/catalog.yaml" {
+			buf := make([]byte, hdr.Size)
+			if _, err := io.ReadFull(tr, buf); err != nil {
+				return nil, err
+			}
+			return buf, nil
+		}
+	}
+}
+
+func splitPath(p string) (dir, base string) {
+	i := strings.LastIndex(p, "/")
+	if i < 0 {
+		return "", p
+	}
+	return p[:i+1], p[i+1:]
+}
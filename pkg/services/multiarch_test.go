@@ -0,0 +1,215 @@
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+)
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Platform
+		wantErr bool
+	}{
+		{"linux/amd64", Platform{OS: "linux", Architecture: "amd64"}, false},
+		{"linux/arm64/v8", Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}, false},
+		{"linux", Platform{}, true},
+		{"linux/arm64/v8/extra", Platform{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePlatform(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePlatform(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("ParsePlatform(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSelectManifests(t *testing.T) {
+	idx := ImageIndex{
+		MediaType: MediaTypeImageIndex,
+		Manifests: []ManifestDescriptor{
+			{Digest: "sha256:amd64", Platform: PlatformDescriptor{OS: "linux", Architecture: "amd64"}},
+			{Digest: "sha256:arm64", Platform: PlatformDescriptor{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+			{Digest: "sha256:ppc64le", Platform: PlatformDescriptor{OS: "linux", Architecture: "ppc64le"}},
+		},
+	}
+
+	t.Run("all platforms", func(t *testing.T) {
+		got, err := SelectManifests(idx, nil, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != len(idx.Manifests) {
+			t.Fatalf("got %d manifests, want %d", len(got), len(idx.Manifests))
+		}
+	})
+
+	t.Run("filtered platforms", func(t *testing.T) {
+		platforms := []Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "ppc64le"}}
+		got, err := SelectManifests(idx, platforms, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("got %d manifests, want 2", len(got))
+		}
+		if got[0].Digest != "sha256:amd64" || got[1].Digest != "sha256:ppc64le" {
+			t.Fatalf("unexpected selection: %+v", got)
+		}
+	})
+
+	t.Run("no platforms specified", func(t *testing.T) {
+		if _, err := SelectManifests(idx, nil, false); err == nil {
+			t.Fatal("expected error when no platforms or --all-platforms given")
+		}
+	})
+
+	t.Run("no requested platform present", func(t *testing.T) {
+		if _, err := SelectManifests(idx, []Platform{{OS: "windows", Architecture: "amd64"}}, false); err == nil {
+			t.Fatal("expected error when none of the requested platforms match")
+		}
+	})
+}
+
+// fakeRegistryClient is an in-memory RegistryClient used to exercise
+// MirrorImageIndex without a real registry. It can be configured to serve
+// either a Docker v2s2 manifest list or an OCI image index.
+type fakeRegistryClient struct {
+	index    ImageIndex
+	copied   []string
+	pushedTo string
+	pushed   ImageIndex
+}
+
+func (f *fakeRegistryClient) GetIndex(ctx context.Context, ref string) (ImageIndex, error) {
+	return f.index, nil
+}
+
+func (f *fakeRegistryClient) CopyManifest(ctx context.Context, src, dst, digest string) (string, error) {
+	f.copied = append(f.copied, digest)
+	return digest, nil
+}
+
+func (f *fakeRegistryClient) PutIndex(ctx context.Context, dst string, idx ImageIndex) (string, error) {
+	f.pushedTo = dst
+	f.pushed = idx
+	return "sha256:reassembled", nil
+}
+
+func TestMirrorImageIndexDockerManifestList(t *testing.T) {
+	client := &fakeRegistryClient{index: ImageIndex{
+		MediaType: MediaTypeDockerManifestList,
+		Manifests: []ManifestDescriptor{
+			{Digest: "sha256:amd64", Platform: PlatformDescriptor{OS: "linux", Architecture: "amd64"}},
+			{Digest: "sha256:arm64", Platform: PlatformDescriptor{OS: "linux", Architecture: "arm64"}},
+		},
+	}}
+
+	digest, err := MirrorImageIndex(context.Background(), client, "src/repo:tag", "dst/repo:tag",
+		[]Platform{{OS: "linux", Architecture: "amd64"}}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if digest != "sha256:reassembled" {
+		t.Fatalf("got digest %s, want sha256:reassembled", digest)
+	}
+	if len(client.copied) != 1 || client.copied[0] != "sha256:amd64" {
+		t.Fatalf("unexpected copied manifests: %v", client.copied)
+	}
+	if len(client.pushed.Manifests) != 1 {
+		t.Fatalf("expected reassembled index to reference 1 manifest, got %d", len(client.pushed.Manifests))
+	}
+}
+
+func TestMirrorImageIndexOCIImageIndex(t *testing.T) {
+	client := &fakeRegistryClient{index: ImageIndex{
+		MediaType: MediaTypeImageIndex,
+		Manifests: []ManifestDescriptor{
+			{Digest: "sha256:amd64", Platform: PlatformDescriptor{OS: "linux", Architecture: "amd64"}},
+			{Digest: "sha256:arm64", Platform: PlatformDescriptor{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+		},
+	}}
+
+	_, err := MirrorImageIndex(context.Background(), client, "src/repo:tag", "dst/repo:tag", nil, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.copied) != 2 {
+		t.Fatalf("expected both platforms copied with --all-platforms, got %v", client.copied)
+	}
+}
+
+func TestMirrorImageIndexRejectsSingleManifest(t *testing.T) {
+	client := &fakeRegistryClient{index: ImageIndex{MediaType: "application/vnd.oci.image.manifest.v1+json"}}
+	if _, err := MirrorImageIndex(context.Background(), client, "src", "dst", nil, true); err == nil {
+		t.Fatal("expected error for a non-multi-arch manifest")
+	}
+}
+
+// gzipTarLayer builds a gzip-compressed tar layer from the given files,
+// simulating a go-containerregistry layer reader's input.
+func gzipTarLayer(t *testing.T, files map[string]string) io.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Size: int64(len(content)), Mode: 0644}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("writing tar header for %s: %v", name, err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("writing tar content for %s: %v", name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("closing tar writer: %v", err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatalf("closing gzip writer: %v", err)
+	}
+	return &buf
+}
+
+func TestExtractCatalogConfigs(t *testing.T) {
+	platform := Platform{OS: "linux", Architecture: "amd64"}
+
+	t.Run("found in innermost layer", func(t *testing.T) {
+		layers := []io.Reader{
+			gzipTarLayer(t, map[string]string{"configs/catalog.json": `{"name":"base"}`}),
+			gzipTarLayer(t, map[string]string{"other/file": "noop"}),
+		}
+		data, err := ExtractCatalogConfigs(platform, layers)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(data) != `{"name":"base"}` {
+			t.Fatalf("got %q, want base catalog contents", data)
+		}
+	})
+
+	t.Run("whiteout hides earlier layer's file", func(t *testing.T) {
+		layers := []io.Reader{
+			gzipTarLayer(t, map[string]string{"configs/catalog.json": `{"name":"base"}`}),
+			gzipTarLayer(t, map[string]string{"configs/.wh.catalog.json": ""}),
+		}
+		if _, err := ExtractCatalogConfigs(platform, layers); err == nil {
+			t.Fatal("expected catalog to be hidden by a later whiteout layer")
+		}
+	})
+
+	t.Run("not present in any layer", func(t *testing.T) {
+		layers := []io.Reader{gzipTarLayer(t, map[string]string{"other/file": "noop"})}
+		if _, err := ExtractCatalogConfigs(platform, layers); err == nil {
+			t.Fatal("expected error when no catalog file is present")
+		}
+	})
+}
@@ -20,9 +20,18 @@ import (
 
 type RootOptions struct {
 	genericclioptions.IOStreams
-	Dir            string
-	LogLevel       int
-	logfileCleanup func()
+	Dir              string
+	LogLevel         int
+	logfileCleanup   func()
+	errOutIsTerminal bool
+}
+
+// ErrOutIsTerminal reports whether stderr was a terminal when LogfilePreRun
+// ran, before it got wrapped to also write to the log file. Callers that
+// need to auto-detect interactivity (e.g. progress reporting) should use
+// this instead of inspecting o.IOStreams.ErrOut directly.
+func (o *RootOptions) ErrOutIsTerminal() bool {
+	return o.errOutIsTerminal
 }
 
 func (o *RootOptions) BindFlags(fs *pflag.FlagSet) {
@@ -34,6 +43,8 @@ func (o *RootOptions) BindFlags(fs *pflag.FlagSet) {
 }
 
 func (o *RootOptions) LogfilePreRun(cmd *cobra.Command, _ []string) {
+	o.errOutIsTerminal = isTerminal(o.IOStreams.ErrOut)
+
 	var fsv2 flag.FlagSet
 	// Configure klog flags
 	klog.InitFlags(&fsv2)
@@ -102,28 +113,37 @@ func checkErr(err error) {
 
 type MirrorOptions struct {
 	*RootOptions
-	OutputDir                  string
-	ConfigPath                 string
-	SkipImagePin               bool
-	ManifestsOnly              bool
-	From                       string
-	ToMirror                   string
-	UserNamespace              string
-	DryRun                     bool
-	SourceSkipTLS              bool
-	DestSkipTLS                bool
-	SourcePlainHTTP            bool
-	DestPlainHTTP              bool
-	SkipVerification           bool
-	SkipCleanup                bool
-	SkipMissing                bool
-	SkipMetadataCheck          bool
-	ContinueOnError            bool
-	IgnoreHistory              bool
-	MaxPerRegistry             int
-	UseOCIFeature              bool
-	OCIRegistriesConfig        string
-	OCIInsecureSignaturePolicy bool
+	OutputDir           string
+	ConfigPath          string
+	SkipImagePin        bool
+	ManifestsOnly       bool
+	From                string
+	ToMirror            string
+	UserNamespace       string
+	DryRun              bool
+	SourceSkipTLS       bool
+	DestSkipTLS         bool
+	SourcePlainHTTP     bool
+	DestPlainHTTP       bool
+	SkipVerification    bool
+	SkipCleanup         bool
+	SkipMissing         bool
+	SkipMetadataCheck   bool
+	ContinueOnError     bool
+	IgnoreHistory       bool
+	MaxPerRegistry      int
+	UseOCIFeature       bool
+	OCIRegistriesConfig string
+	RegistriesConf      string
+	Platforms           []string
+	AllPlatforms        bool
+	CopySignatures      bool
+	CopyAttestations    bool
+	SignaturePolicy     string
+	SigstoreTUFMirror   string
+	Progress            string
+	EventsFile          string
+	Resume              bool
 	// cancelCh is a channel listening for command cancellations
 	cancelCh         <-chan struct{}
 	once             sync.Once
@@ -134,7 +154,8 @@ type MirrorOptions struct {
 func (o *MirrorOptions) BindFlags(fs *pflag.FlagSet) {
 	fs.StringVarP(&o.ConfigPath, "config", "c", o.ConfigPath, "Path to imageset configuration file")
 	fs.BoolVar(&o.SkipImagePin, "skip-image-pin", o.SkipImagePin, "Do not replace image tags with digest pins in operator catalogs")
-	fs.StringVar(&o.From, "from", o.From, "Path to an input file (e.g. archived imageset)")
+	fs.StringVar(&o.From, "from", o.From, "Path to an input file (e.g. archived imageset), or an oras://<registry>/<repo>:<tag> "+
+		"reference to pull a workspace staged in a registry")
 	fs.BoolVar(&o.ManifestsOnly, "manifests-only", o.ManifestsOnly, "Generate manifests and do not mirror")
 	fs.BoolVar(&o.DryRun, "dry-run", o.DryRun, "Print actions without mirroring images")
 	fs.BoolVar(&o.SourceSkipTLS, "source-skip-tls", o.SourceSkipTLS, "Disable TLS validation for source registry")
@@ -156,7 +177,23 @@ func (o *MirrorOptions) BindFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&o.MaxPerRegistry, "max-per-registry", 6, "Number of concurrent requests allowed per registry")
 	fs.BoolVar(&o.UseOCIFeature, "use-oci-feature", o.UseOCIFeature, "Use the new oci feature for oc mirror (oci formatted copy")
 	fs.StringVar(&o.OCIRegistriesConfig, "oci-registries-config", o.OCIRegistriesConfig, "Registries config file location (used only with --use-oci-feature flag)")
-	fs.BoolVar(&o.OCIInsecureSignaturePolicy, "oci-insecure-signature-policy", o.OCIInsecureSignaturePolicy, "If set, OCI catalog push will not try to push signatures")
+	fs.StringSliceVar(&o.Platforms, "platforms", o.Platforms, "Comma separated list of platforms (os/arch[/variant]) to mirror "+
+		"from a multi-arch manifest list or OCI image index, e.g. linux/amd64,linux/arm64,linux/ppc64le")
+	fs.BoolVar(&o.AllPlatforms, "all-platforms", o.AllPlatforms, "Mirror every platform referenced by a manifest list or OCI image index "+
+		"instead of only the ones in --platforms")
+	fs.BoolVar(&o.CopySignatures, "copy-signatures", o.CopySignatures, "Discover and copy cosign signatures alongside every mirrored image")
+	fs.BoolVar(&o.CopyAttestations, "copy-attestations", o.CopyAttestations, "Discover and copy cosign attestations and SBOMs alongside every mirrored image")
+	fs.StringVar(&o.SignaturePolicy, "signature-policy", o.SignaturePolicy, "Path to a containers/image signature/policy.json file used to verify "+
+		"signatures before copying (used only with --copy-signatures)")
+	fs.StringVar(&o.SigstoreTUFMirror, "sigstore-tuf-mirror", o.SigstoreTUFMirror, "URL of the Sigstore TUF repository mirror used to fetch "+
+		"root trust material for signature verification")
+	fs.StringVar(&o.RegistriesConf, "registries-conf", o.RegistriesConf, "Path to a containers-registries.conf(5) v2 TOML file used to "+
+		"remap and mirror image references for both the classic and --use-oci-feature mirror paths")
+	fs.StringVar(&o.Progress, "progress", o.Progress, "Progress reporting mode: auto, plain, tty or json")
+	fs.StringVar(&o.EventsFile, "events-file", o.EventsFile, "Path to an NDJSON file to append one progress event per state transition to. "+
+		"Required for --resume")
+	fs.BoolVar(&o.Resume, "resume", o.Resume, "Re-read --events-file and skip (source, destination, digest) triples that already "+
+		"completed successfully on a previous run")
 }
 
 func (o *MirrorOptions) init() {
@@ -191,4 +228,4 @@ func makeCancelCh(signals ...os.Signal) <-chan struct{} {
 		}
 	}()
 	return resultCh
-}
\ No newline at end of file
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/klog/v2"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/retry"
+)
+
+// OrasTransportPrefix is the scheme MirrorOptions.From and MirrorOptions.ToMirror
+// recognize to select the OCI-layout-over-registry transport implemented here,
+// e.g. "oras://registry.example.com/ns/imageset:latest".
+const OrasTransportPrefix = "oras://"
+
+// IsOrasRef reports whether ref uses the oras:// transport.
+func IsOrasRef(ref string) bool {
+	return strings.HasPrefix(ref, OrasTransportPrefix)
+}
+
+// ParseOrasRef splits an "oras://<registry>/<repo>:<tag>" reference into the
+// repository reference oras-go expects.
+func ParseOrasRef(ref string) (string, error) {
+	if !IsOrasRef(ref) {
+		return "", fmt.Errorf("%s is not an oras:// reference", ref)
+	}
+	return strings.TrimPrefix(ref, OrasTransportPrefix), nil
+}
+
+// orasRepository opens the remote repository identified by ref, configured
+// the same way as the rest of the mirror pipeline's registry transports.
+func orasRepository(ref string, skipTLS, plainHTTP bool) (*remote.Repository, error) {
+	repo, err := remote.NewRepository(ref)
+	if err != nil {
+		return nil, fmt.Errorf("opening oras repository %s: %w", ref, err)
+	}
+	repo.PlainHTTP = plainHTTP
+
+	client := retry.DefaultClient
+	if skipTLS {
+		klog.Warningf("TLS validation disabled for %s", ref)
+		client = &http.Client{
+			Transport: retry.NewTransport(&http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}),
+		}
+	}
+	repo.Client = &auth.Client{
+		Client: client,
+	}
+	return repo, nil
+}
+
+// PushWorkspace walks every oci-layout directory under workspaceDir and
+// copies it to ref as a single artifact tree, preserving manifest digests
+// and artifact media types, so an imageset workspace can be staged in a
+// registry instead of archived to a tarball.
+func PushWorkspace(ctx context.Context, workspaceDir, ref string, skipTLS, plainHTTP bool) error {
+	target, err := orasRepository(ref, skipTLS, plainHTTP)
+	if err != nil {
+		return err
+	}
+
+	layouts, err := findOCILayouts(workspaceDir)
+	if err != nil {
+		return fmt.Errorf("scanning %s for oci-layout directories: %w", workspaceDir, err)
+	}
+	if len(layouts) == 0 {
+		return fmt.Errorf("no oci-layout directories found under %s", workspaceDir)
+	}
+
+	for _, layoutDir := range layouts {
+		if err := pushLayout(ctx, layoutDir, target); err != nil {
+			return fmt.Errorf("pushing %s to %s: %w", layoutDir, ref, err)
+		}
+		klog.Infof("pushed %s to %s", layoutDir, ref)
+	}
+	return nil
+}
+
+// pushLayout copies every tagged manifest in layoutDir's index.json to target,
+// preserving the original digests and media types.
+func pushLayout(ctx context.Context, layoutDir string, target oras.Target) error {
+	src, err := oci.NewFromFS(ctx, os.DirFS(layoutDir))
+	if err != nil {
+		return err
+	}
+
+	tags, err := listTags(src)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := oras.Copy(ctx, src, tag, target, tag, oras.DefaultCopyOptions); err != nil {
+			return fmt.Errorf("copying tag %s: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// PullWorkspace materializes ref's artifact tree back into an oci-layout
+// directory under workspaceDir, named after the repository path, so
+// subsequent mirror steps can operate on it unchanged.
+func PullWorkspace(ctx context.Context, ref, workspaceDir string, skipTLS, plainHTTP bool) (string, error) {
+	source, err := orasRepository(ref, skipTLS, plainHTTP)
+	if err != nil {
+		return "", err
+	}
+
+	layoutDir := filepath.Join(workspaceDir, sanitizeRefPath(source.Reference.Repository))
+	if err := os.MkdirAll(layoutDir, 0750); err != nil {
+		return "", fmt.Errorf("creating %s: %w", layoutDir, err)
+	}
+
+	dst, err := oci.New(layoutDir)
+	if err != nil {
+		return "", fmt.Errorf("initializing oci-layout at %s: %w", layoutDir, err)
+	}
+
+	tag := source.Reference.Reference
+	if _, err := oras.Copy(ctx, source, tag, dst, tag, oras.DefaultCopyOptions); err != nil {
+		return "", fmt.Errorf("pulling %s: %w", ref, err)
+	}
+
+	klog.Infof("pulled %s into %s", ref, layoutDir)
+	return layoutDir, nil
+}
+
+// findOCILayouts returns every directory under root containing an
+// "oci-layout" marker file, in deterministic (sorted) walk order. The
+// presence of index.json is not checked.
+func findOCILayouts(root string) ([]string, error) {
+	var layouts []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if _, statErr := os.Stat(filepath.Join(path, "oci-layout")); statErr == nil {
+				layouts = append(layouts, path)
+			}
+		}
+		return nil
+	})
+	return layouts, err
+}
+
+// tagLister is implemented by both *oci.Store and *oci.ReadOnlyStore -
+// oci.NewFromFS returns the latter, so listTags takes this narrower
+// interface rather than either concrete type.
+type tagLister interface {
+	Tags(ctx context.Context, last string, fn func(tags []string) error) error
+}
+
+// listTags returns every tag present in src's index.json.
+func listTags(src tagLister) ([]string, error) {
+	var tags []string
+	err := src.Tags(context.Background(), "", func(found []string) error {
+		tags = append(tags, found...)
+		return nil
+	})
+	return tags, err
+}
+
+// sanitizeRefPath turns a repository path like "ns/imageset" into a
+// filesystem-safe directory name.
+func sanitizeRefPath(repoPath string) string {
+	return strings.ReplaceAll(repoPath, "/", "_")
+}
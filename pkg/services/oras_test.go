@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestIsOrasRef(t *testing.T) {
+	if !IsOrasRef("oras://registry.example.com/ns/imageset:latest") {
+		t.Error("expected an oras:// ref to be recognized")
+	}
+	if IsOrasRef("docker://registry.example.com/ns/imageset:latest") {
+		t.Error("expected a docker:// ref not to be recognized as oras")
+	}
+}
+
+func TestParseOrasRef(t *testing.T) {
+	got, err := ParseOrasRef("oras://registry.example.com/ns/imageset:latest")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "registry.example.com/ns/imageset:latest"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := ParseOrasRef("docker://registry.example.com/ns/imageset:latest"); err == nil {
+		t.Error("expected an error for a non-oras:// reference")
+	}
+}
+
+func TestSanitizeRefPath(t *testing.T) {
+	if got, want := sanitizeRefPath("ns/imageset"), "ns_imageset"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFindOCILayouts(t *testing.T) {
+	root := t.TempDir()
+
+	layoutA := filepath.Join(root, "imageset-a")
+	layoutB := filepath.Join(root, "nested", "imageset-b")
+	notALayout := filepath.Join(root, "not-a-layout")
+
+	for _, dir := range []string{layoutA, layoutB, notALayout} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("creating %s: %v", dir, err)
+		}
+	}
+	for _, dir := range []string{layoutA, layoutB} {
+		if err := os.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0644); err != nil {
+			t.Fatalf("writing oci-layout marker in %s: %v", dir, err)
+		}
+	}
+
+	got, err := findOCILayouts(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sort.Strings(got)
+
+	want := []string{layoutA, layoutB}
+	sort.Strings(want)
+
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("layout %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+// fakeTagLister is a minimal tagLister used to test listTags without a real
+// OCI layout store.
+type fakeTagLister struct {
+	tags []string
+}
+
+func (f *fakeTagLister) Tags(ctx context.Context, last string, fn func(tags []string) error) error {
+	return fn(f.tags)
+}
+
+func TestListTags(t *testing.T) {
+	src := &fakeTagLister{tags: []string{"latest", "v1.0.0"}}
+	got, err := listTags(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "latest" || got[1] != "v1.0.0" {
+		t.Fatalf("got %v, want [latest v1.0.0]", got)
+	}
+}
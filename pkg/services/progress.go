@@ -0,0 +1,282 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// EventType identifies the kind of state transition a ProgressReporter is
+// notified of.
+type EventType string
+
+const (
+	EventImageStarted  EventType = "image_started"
+	EventLayerProgress EventType = "layer_progress"
+	EventImageCopied   EventType = "image_copied"
+	EventImageSkipped  EventType = "image_skipped"
+	EventImageFailed   EventType = "image_failed"
+)
+
+// Event is a single NDJSON record describing one mirror state transition.
+type Event struct {
+	Type     EventType `json:"type"`
+	Time     time.Time `json:"time"`
+	Source   string    `json:"source"`
+	Dest     string    `json:"dest"`
+	Digest   string    `json:"digest,omitempty"`
+	Size     int64     `json:"size,omitempty"`
+	Done     int64     `json:"done,omitempty"`
+	Total    int64     `json:"total,omitempty"`
+	Worker   int       `json:"worker,omitempty"`
+	Duration string    `json:"duration,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// completedKey is the (source, dest, digest) triple --resume uses to decide
+// whether an image can be skipped on rerun.
+type completedKey struct {
+	Source, Dest, Digest string
+}
+
+// ProgressReporter is notified of every mirror state transition so it can
+// render progress or persist an event log. Implementations must be safe for
+// concurrent use by the parallel copy workers.
+type ProgressReporter interface {
+	Report(e Event)
+	Close() error
+}
+
+// NewProgressReporter builds the ProgressReporter selected by o.Progress,
+// tee'ing events to o.EventsFile when set. mode "auto" picks "tty" when
+// stderr is a terminal and "plain" otherwise.
+func NewProgressReporter(o *MirrorOptions) (ProgressReporter, error) {
+	if o.Resume && o.EventsFile == "" {
+		return nil, fmt.Errorf("--resume requires --events-file")
+	}
+
+	mode := o.Progress
+	if mode == "" || mode == "auto" {
+		if o.ErrOutIsTerminal() {
+			mode = "tty"
+		} else {
+			mode = "plain"
+		}
+	}
+
+	var reporter ProgressReporter
+	switch mode {
+	case "plain":
+		reporter = &plainReporter{out: o.IOStreams.ErrOut}
+	case "tty":
+		reporter = &ttyReporter{out: o.IOStreams.ErrOut, workers: map[int]Event{}}
+	case "json":
+		reporter = &jsonReporter{out: o.IOStreams.ErrOut}
+	default:
+		return nil, fmt.Errorf("unknown --progress mode %q, expected auto, plain, tty or json", mode)
+	}
+
+	if o.EventsFile == "" {
+		return reporter, nil
+	}
+
+	f, err := os.OpenFile(o.EventsFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening events file %s: %w", o.EventsFile, err)
+	}
+	return &teeReporter{primary: reporter, events: &jsonReporter{out: f}, file: f}, nil
+}
+
+// isTerminal is a minimal, dependency-free terminal check good enough to
+// pick a sane default; an explicit --progress always overrides it.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// plainReporter prints one line per image state transition, like a
+// non-interactive `skopeo copy`; layer_progress events are not printed.
+type plainReporter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (r *plainReporter) Report(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch e.Type {
+	case EventImageStarted:
+		fmt.Fprintf(r.out, "Copying %s -> %s\n", e.Source, e.Dest)
+	case EventImageCopied:
+		fmt.Fprintf(r.out, "Copied %s -> %s (%s)\n", e.Source, e.Dest, e.Duration)
+	case EventImageSkipped:
+		fmt.Fprintf(r.out, "Skipped %s (already mirrored)\n", e.Source)
+	case EventImageFailed:
+		fmt.Fprintf(r.out, "Failed %s -> %s: %s\n", e.Source, e.Dest, e.Error)
+	}
+}
+
+func (r *plainReporter) Close() error { return nil }
+
+// ttyReporter renders a multi-line, in-place progress display with one row
+// per concurrent worker, similar to skopeo copy's interactive output.
+type ttyReporter struct {
+	mu      sync.Mutex
+	out     io.Writer
+	workers map[int]Event
+	rows    int
+}
+
+func (r *ttyReporter) Report(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch e.Type {
+	case EventImageCopied, EventImageSkipped, EventImageFailed:
+		delete(r.workers, e.Worker)
+	default:
+		r.workers[e.Worker] = e
+	}
+	r.redraw()
+}
+
+// redraw rewrites the previously printed rows in place using ANSI cursor
+// movement, then prints one row per active worker, sorted by worker id so
+// each worker keeps a stable row across redraws instead of swapping places
+// with Go's randomized map iteration order.
+func (r *ttyReporter) redraw() {
+	if r.rows > 0 {
+		fmt.Fprintf(r.out, "\033[%dA\033[J", r.rows)
+	}
+	r.rows = 0
+
+	ids := make([]int, 0, len(r.workers))
+	for worker := range r.workers {
+		ids = append(ids, worker)
+	}
+	sort.Ints(ids)
+
+	for _, worker := range ids {
+		e := r.workers[worker]
+		pct := 0
+		if e.Total > 0 {
+			pct = int(100 * e.Done / e.Total)
+		}
+		fmt.Fprintf(r.out, "[worker %d] %s  %d%% (%d/%d bytes)\n", worker, e.Source, pct, e.Done, e.Total)
+		r.rows++
+	}
+}
+
+func (r *ttyReporter) Close() error { return nil }
+
+// jsonReporter emits one NDJSON record per event and is the format used for
+// the persisted --events-file as well as `--progress=json`.
+type jsonReporter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (r *jsonReporter) Report(e Event) {
+	if e.Time.IsZero() {
+		e.Time = timeNow()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.Marshal(e)
+	if err != nil {
+		klog.Warningf("marshaling progress event: %v", err)
+		return
+	}
+	data = append(data, '\n')
+	if _, err := r.out.Write(data); err != nil {
+		klog.Warningf("writing progress event: %v", err)
+	}
+}
+
+func (r *jsonReporter) Close() error {
+	if c, ok := r.out.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// teeReporter forwards every event to a human-facing reporter and persists
+// it as NDJSON to the events file.
+type teeReporter struct {
+	primary ProgressReporter
+	events  *jsonReporter
+	file    *os.File
+}
+
+func (r *teeReporter) Report(e Event) {
+	r.primary.Report(e)
+	r.events.Report(e)
+}
+
+func (r *teeReporter) Close() error {
+	err1 := r.primary.Close()
+	err2 := r.file.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// LoadCompletedTriples re-reads an --events-file written by a previous run
+// and returns the (source, dest, digest) triples whose last image_copied or
+// image_failed event is image_copied, so a --resume run can skip them.
+func LoadCompletedTriples(path string) (map[completedKey]bool, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return map[completedKey]bool{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening events file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	completed := map[completedKey]bool{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			klog.Warningf("skipping malformed events file line: %v", err)
+			continue
+		}
+		key := completedKey{Source: e.Source, Dest: e.Dest, Digest: e.Digest}
+		switch e.Type {
+		case EventImageCopied:
+			completed[key] = true
+		case EventImageFailed:
+			delete(completed, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading events file %s: %w", path, err)
+	}
+	return completed, nil
+}
+
+// ShouldSkip reports whether --resume should skip copying src to dst at
+// digest, because a previous run already completed it successfully.
+func ShouldSkip(completed map[completedKey]bool, src, dst, digest string) bool {
+	return completed[completedKey{Source: src, Dest: dst, Digest: digest}]
+}
+
+// timeNow is a thin indirection so tests can stub the clock if needed.
+var timeNow = time.Now
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func TestJSONReporterWritesNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	r := &jsonReporter{out: &buf}
+
+	r.Report(Event{Type: EventImageStarted, Source: "src:tag", Dest: "dst:tag"})
+	r.Report(Event{Type: EventImageCopied, Source: "src:tag", Dest: "dst:tag", Digest: "sha256:abcd"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	var first Event
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("unmarshaling first event: %v", err)
+	}
+	if first.Type != EventImageStarted || first.Source != "src:tag" {
+		t.Errorf("got %+v, want image_started for src:tag", first)
+	}
+}
+
+func TestPlainReporter(t *testing.T) {
+	var buf bytes.Buffer
+	r := &plainReporter{out: &buf}
+
+	r.Report(Event{Type: EventImageStarted, Source: "src:tag", Dest: "dst:tag"})
+	r.Report(Event{Type: EventImageCopied, Source: "src:tag", Dest: "dst:tag", Duration: "1s"})
+
+	out := buf.String()
+	if !strings.Contains(out, "Copying src:tag -> dst:tag") {
+		t.Errorf("missing started line in output: %q", out)
+	}
+	if !strings.Contains(out, "Copied src:tag -> dst:tag (1s)") {
+		t.Errorf("missing copied line in output: %q", out)
+	}
+}
+
+func TestLoadCompletedTriplesAndShouldSkip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.ndjson")
+
+	events := []Event{
+		{Type: EventImageStarted, Source: "a:tag", Dest: "dst/a:tag"},
+		{Type: EventImageCopied, Source: "a:tag", Dest: "dst/a:tag", Digest: "sha256:a"},
+		{Type: EventImageCopied, Source: "b:tag", Dest: "dst/b:tag", Digest: "sha256:b"},
+		{Type: EventImageFailed, Source: "b:tag", Dest: "dst/b:tag", Digest: "sha256:b", Error: "boom"},
+	}
+	var buf bytes.Buffer
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Fatalf("marshaling event: %v", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("writing events file: %v", err)
+	}
+
+	completed, err := LoadCompletedTriples(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !ShouldSkip(completed, "a:tag", "dst/a:tag", "sha256:a") {
+		t.Error("expected a:tag to be marked completed")
+	}
+	if ShouldSkip(completed, "b:tag", "dst/b:tag", "sha256:b") {
+		t.Error("expected b:tag's later failure to un-mark it as completed")
+	}
+	if ShouldSkip(completed, "c:tag", "dst/c:tag", "sha256:c") {
+		t.Error("expected an untouched triple not to be marked completed")
+	}
+}
+
+func TestLoadCompletedTriplesMissingFile(t *testing.T) {
+	completed, err := LoadCompletedTriples(filepath.Join(t.TempDir(), "missing.ndjson"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(completed) != 0 {
+		t.Fatalf("expected an empty map for a missing events file, got %v", completed)
+	}
+}
+
+func TestNewProgressReporterResumeRequiresEventsFile(t *testing.T) {
+	o := &MirrorOptions{RootOptions: &RootOptions{IOStreams: genericclioptions.IOStreams{ErrOut: &bytes.Buffer{}}}, Resume: true}
+	if _, err := NewProgressReporter(o); err == nil {
+		t.Fatal("expected an error when --resume is set without --events-file")
+	}
+}
+
+func TestNewProgressReporterUnknownMode(t *testing.T) {
+	o := &MirrorOptions{RootOptions: &RootOptions{IOStreams: genericclioptions.IOStreams{ErrOut: &bytes.Buffer{}}}, Progress: "bogus"}
+	if _, err := NewProgressReporter(o); err == nil {
+		t.Fatal("expected an error for an unrecognized --progress mode")
+	}
+}
+
+func TestNewProgressReporterPlainMode(t *testing.T) {
+	o := &MirrorOptions{RootOptions: &RootOptions{IOStreams: genericclioptions.IOStreams{ErrOut: &bytes.Buffer{}}}, Progress: "plain"}
+	reporter, err := NewProgressReporter(o)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := reporter.(*plainReporter); !ok {
+		t.Fatalf("got %T, want *plainReporter", reporter)
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"k8s.io/klog/v2"
+)
+
+// registryEntry is the raw TOML shape of a single [[registry]] block in a
+// containers-registries.conf(5) v2 file.
+type registryEntry struct {
+	Location           string        `toml:"location"`
+	Prefix             string        `toml:"prefix"`
+	Insecure           bool          `toml:"insecure"`
+	Blocked            bool          `toml:"blocked"`
+	MirrorByDigestOnly bool          `toml:"mirror-by-digest-only"`
+	PullFromMirror     string        `toml:"pull-from-mirror"`
+	Mirror             []mirrorEntry `toml:"mirror"`
+}
+
+type mirrorEntry struct {
+	Location       string `toml:"location"`
+	Insecure       bool   `toml:"insecure"`
+	PullFromMirror string `toml:"pull-from-mirror"`
+}
+
+// rawRegistriesConf is the top level shape of a registries.conf(5) v2 file.
+type rawRegistriesConf struct {
+	Registries []registryEntry `toml:"registry"`
+}
+
+// Registry is one [[registry]] block, resolved and ready to be matched
+// against image references.
+type Registry struct {
+	Location           string
+	Prefix             string
+	Insecure           bool
+	Blocked            bool
+	MirrorByDigestOnly bool
+	PullFromMirror     string
+	Mirrors            []Mirror
+}
+
+// Mirror is a single mirror of a Registry, tried in the order configured
+// before falling back to the registry's own Location.
+type Mirror struct {
+	Location       string
+	Insecure       bool
+	PullFromMirror string
+}
+
+// scopeKey is whichever of Prefix or Location a registry entry should be
+// matched against - prefix takes precedence per registries.conf(5).
+func (r Registry) scopeKey() string {
+	if r.Prefix != "" {
+		return r.Prefix
+	}
+	return r.Location
+}
+
+// isDigestRef reports whether ref pins a digest (name@sha256:...) rather
+// than a tag, which is what "pull-from-mirror" values of digest-only/tag-only
+// discriminate on.
+func isDigestRef(ref string) bool {
+	return strings.Contains(ref, "@")
+}
+
+// effectivePullFromMirror resolves m's "pull-from-mirror" setting, falling
+// back to reg's registry-level default, and finally to "all" (the
+// registries.conf(5) default of always allowing the mirror).
+func effectivePullFromMirror(reg Registry, m Mirror) string {
+	if m.PullFromMirror != "" {
+		return m.PullFromMirror
+	}
+	if reg.PullFromMirror != "" {
+		return reg.PullFromMirror
+	}
+	return "all"
+}
+
+// RegistriesConfig is the in-memory, queryable form of a parsed
+// containers-registries.conf(5) v2 file.
+type RegistriesConfig struct {
+	registries []Registry
+}
+
+// LoadRegistriesConfig parses the v2 TOML file at path into a RegistriesConfig.
+// A path of "" returns an empty configuration that resolves every reference
+// unchanged, so callers can unconditionally wire it through the pipeline.
+func LoadRegistriesConfig(path string) (*RegistriesConfig, error) {
+	if path == "" {
+		return &RegistriesConfig{}, nil
+	}
+
+	var raw rawRegistriesConf
+	if _, err := toml.DecodeFile(path, &raw); err != nil {
+		return nil, fmt.Errorf("parsing registries config %s: %w", path, err)
+	}
+
+	cfg := &RegistriesConfig{}
+	for _, re := range raw.Registries {
+		reg := Registry{
+			Location:           re.Location,
+			Prefix:             re.Prefix,
+			Insecure:           re.Insecure,
+			Blocked:            re.Blocked,
+			MirrorByDigestOnly: re.MirrorByDigestOnly,
+			PullFromMirror:     re.PullFromMirror,
+		}
+		for _, me := range re.Mirror {
+			reg.Mirrors = append(reg.Mirrors, Mirror{
+				Location:       me.Location,
+				Insecure:       me.Insecure,
+				PullFromMirror: me.PullFromMirror,
+			})
+		}
+		cfg.registries = append(cfg.registries, reg)
+	}
+
+	// Sort longest scope first so lookups can take the first match.
+	sort.SliceStable(cfg.registries, func(i, j int) bool {
+		return len(cfg.registries[i].scopeKey()) > len(cfg.registries[j].scopeKey())
+	})
+
+	return cfg, nil
+}
+
+// lookup returns the longest-prefix-matching Registry entry for ref, if any.
+func (c *RegistriesConfig) lookup(ref string) (Registry, bool) {
+	if c == nil {
+		return Registry{}, false
+	}
+	for _, reg := range c.registries {
+		if strings.HasPrefix(ref, reg.scopeKey()) {
+			return reg, true
+		}
+	}
+	return Registry{}, false
+}
+
+// Candidate is one endpoint ResolveRef suggests trying, in priority order.
+type Candidate struct {
+	Ref              string
+	Insecure         bool
+	DigestOnly       bool
+	IsSourceFallback bool
+}
+
+// ResolveRef rewrites ref through cfg's [[registry]] table, returning the
+// ordered candidate endpoints a collector should try: every configured
+// mirror first (in file order), then the original ref itself as a fallback,
+// unless the matching entry is "blocked". mirror-by-digest-only entries mark
+// every mirror candidate (not the source fallback) accordingly so callers can
+// refuse to resolve a tag against them. A mirror whose effective "pull-from-mirror" is digest-only or
+// tag-only is excluded when ref doesn't match that restriction.
+func ResolveRef(ctx context.Context, cfg *RegistriesConfig, ref string) ([]Candidate, error) {
+	reg, ok := cfg.lookup(ref)
+	if !ok {
+		return []Candidate{{Ref: ref}}, nil
+	}
+	if reg.Blocked {
+		return nil, fmt.Errorf("%s is blocked by registries config (scope %s)", ref, reg.scopeKey())
+	}
+
+	var candidates []Candidate
+	suffix := strings.TrimPrefix(ref, reg.scopeKey())
+	byDigest := isDigestRef(ref)
+	for _, m := range reg.Mirrors {
+		switch effectivePullFromMirror(reg, m) {
+		case "digest-only":
+			if !byDigest {
+				continue
+			}
+		case "tag-only":
+			if byDigest {
+				continue
+			}
+		}
+		candidates = append(candidates, Candidate{
+			Ref:        m.Location + suffix,
+			Insecure:   m.Insecure,
+			DigestOnly: reg.MirrorByDigestOnly,
+		})
+	}
+	candidates = append(candidates, Candidate{
+		Ref:              ref,
+		Insecure:         reg.Insecure,
+		IsSourceFallback: len(candidates) > 0,
+	})
+	return candidates, nil
+}
+
+// ResolveWithFallback tries each of ResolveRef's candidates with try in
+// order, returning the first one try reports as usable (e.g. a successful
+// HEAD/GET), falling through on 404/5xx-class errors per registries.conf(5)
+// mirror semantics.
+func ResolveWithFallback(ctx context.Context, cfg *RegistriesConfig, ref string, try func(ctx context.Context, c Candidate) error) (Candidate, error) {
+	candidates, err := ResolveRef(ctx, cfg, ref)
+	if err != nil {
+		return Candidate{}, err
+	}
+
+	var lastErr error
+	for _, c := range candidates {
+		if err := try(ctx, c); err != nil {
+			if !isFallbackError(err) {
+				return Candidate{}, err
+			}
+			klog.Warningf("%s unreachable, trying next candidate: %v", c.Ref, err)
+			lastErr = err
+			continue
+		}
+		return c, nil
+	}
+	return Candidate{}, fmt.Errorf("no candidate for %s succeeded: %w", ref, lastErr)
+}
+
+// statusCoder is implemented by registry transport errors that carry an HTTP
+// status code, such as those returned by containers/image's docker transport.
+type statusCoder interface {
+	StatusCode() int
+}
+
+// isFallbackError reports whether err represents the class of failure
+// (404 or 5xx) that should fall through to the next mirror rather than
+// abort resolution outright. Errors that don't identify an HTTP status -
+// auth failures, timeouts, DNS errors, TLS errors - are not recognized as
+// fallback-eligible and abort resolution instead of being silently retried
+// against the next candidate.
+func isFallbackError(err error) bool {
+	sc, ok := err.(statusCoder)
+	if !ok {
+		return false
+	}
+	code := sc.StatusCode()
+	return code == http.StatusNotFound || code >= http.StatusInternalServerError
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeRegistriesConf(t *testing.T, contents string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "registries.conf")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("writing registries config: %v", err)
+	}
+	return path
+}
+
+func TestLoadRegistriesConfigEmptyPath(t *testing.T) {
+	cfg, err := LoadRegistriesConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	candidates, err := ResolveRef(context.Background(), cfg, "registry.example.com/repo:tag")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].Ref != "registry.example.com/repo:tag" {
+		t.Fatalf("got %+v, want the ref unchanged", candidates)
+	}
+}
+
+func TestResolveRefLongestPrefixMatch(t *testing.T) {
+	path := writeRegistriesConf(t, `
+[[registry]]
+location = "registry.example.com"
+  [[registry.mirror]]
+  location = "mirror.example.com"
+
+[[registry]]
+prefix = "registry.example.com/team"
+  [[registry.mirror]]
+  location = "team-mirror.example.com"
+`)
+	cfg, err := LoadRegistriesConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	candidates, err := ResolveRef(context.Background(), cfg, "registry.example.com/team/app:v1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("got %d candidates, want 2 (mirror + source fallback), got %+v", len(candidates), candidates)
+	}
+	if candidates[0].Ref != "team-mirror.example.com/app:v1" {
+		t.Fatalf("expected the more specific prefix's mirror to win, got %q", candidates[0].Ref)
+	}
+	if !candidates[1].IsSourceFallback || candidates[1].Ref != "registry.example.com/team/app:v1" {
+		t.Fatalf("expected source fallback as the last candidate, got %+v", candidates[1])
+	}
+}
+
+func TestResolveRefDigestOnlyMirror(t *testing.T) {
+	path := writeRegistriesConf(t, `
+[[registry]]
+location = "registry.example.com"
+mirror-by-digest-only = true
+  [[registry.mirror]]
+  location = "mirror.example.com"
+  pull-from-mirror = "digest-only"
+`)
+	cfg, err := LoadRegistriesConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("tag reference skips the digest-only mirror", func(t *testing.T) {
+		candidates, err := ResolveRef(context.Background(), cfg, "registry.example.com/repo:tag")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(candidates) != 1 || candidates[0].IsSourceFallback {
+			t.Fatalf("expected only the source fallback for a tag ref, got %+v", candidates)
+		}
+	})
+
+	t.Run("digest reference uses the mirror", func(t *testing.T) {
+		candidates, err := ResolveRef(context.Background(), cfg, "registry.example.com/repo@sha256:abcd")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(candidates) != 2 || candidates[0].Ref != "mirror.example.com/repo@sha256:abcd" {
+			t.Fatalf("expected the mirror to be tried first for a digest ref, got %+v", candidates)
+		}
+		if !candidates[0].DigestOnly {
+			t.Fatal("expected the candidate to be marked mirror-by-digest-only")
+		}
+	})
+}
+
+func TestResolveRefBlockedRegistry(t *testing.T) {
+	path := writeRegistriesConf(t, `
+[[registry]]
+location = "blocked.example.com"
+blocked = true
+`)
+	cfg, err := LoadRegistriesConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := ResolveRef(context.Background(), cfg, "blocked.example.com/repo:tag"); err == nil {
+		t.Fatal("expected blocked registry to return an error")
+	}
+}
+
+type statusError struct {
+	code int
+}
+
+func (e statusError) Error() string   { return "status error" }
+func (e statusError) StatusCode() int { return e.code }
+
+func TestIsFallbackError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"not found falls back", statusError{code: 404}, true},
+		{"server error falls back", statusError{code: 503}, true},
+		{"client error does not fall back", statusError{code: 401}, false},
+		{"unrecognized error does not fall back", errors.New("connection reset"), false},
+	}
+	for _, tt := range tests {
+		if got := isFallbackError(tt.err); got != tt.want {
+			t.Errorf("%s: isFallbackError() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveWithFallback(t *testing.T) {
+	path := writeRegistriesConf(t, `
+[[registry]]
+location = "registry.example.com"
+  [[registry.mirror]]
+  location = "unreachable-mirror.example.com"
+  [[registry.mirror]]
+  location = "good-mirror.example.com"
+`)
+	cfg, err := LoadRegistriesConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var tried []string
+	winner, err := ResolveWithFallback(context.Background(), cfg, "registry.example.com/repo:tag", func(ctx context.Context, c Candidate) error {
+		tried = append(tried, c.Ref)
+		if c.Ref == "unreachable-mirror.example.com/repo:tag" {
+			return statusError{code: 404}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if winner.Ref != "good-mirror.example.com/repo:tag" {
+		t.Fatalf("got winner %q, want good-mirror.example.com/repo:tag", winner.Ref)
+	}
+	if len(tried) != 2 {
+		t.Fatalf("expected both mirrors to be tried in order, got %v", tried)
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// cosign stores signatures, attestations and SBOMs as OCI artifacts tagged
+// off of the subject's digest, swapping the ":" for a "-" since tags cannot
+// contain colons.
+const (
+	cosignSignatureSuffix   = ".sig"
+	cosignAttestationSuffix = ".att"
+	cosignSBOMSuffix        = ".sbom"
+)
+
+// CosignTag returns the tag cosign uses to attach kind (one of the
+// cosign*Suffix constants above) to the image identified by digest, e.g.
+// "sha256-abcd1234.sig" for digest "sha256:abcd1234" and kind ".sig".
+func CosignTag(digest, kind string) string {
+	return strings.Replace(digest, ":", "-", 1) + kind
+}
+
+// CosignTags returns the signature, attestation and SBOM tags derived from
+// digest, in the order they should be discovered and copied.
+func CosignTags(digest string) []string {
+	return []string{
+		CosignTag(digest, cosignSignatureSuffix),
+		CosignTag(digest, cosignAttestationSuffix),
+		CosignTag(digest, cosignSBOMSuffix),
+	}
+}
+
+// RequirementType is one of the containers/image signature/policy.json
+// requirement kinds this package understands.
+type RequirementType string
+
+const (
+	// RequirementSigstoreSigned requires a valid Sigstore (cosign) signature.
+	RequirementSigstoreSigned RequirementType = "sigstoreSigned"
+	// RequirementSignedBy requires a valid signature from a named key/identity.
+	RequirementSignedBy RequirementType = "signedBy"
+	// RequirementInsecureAcceptAnything accepts images without verification.
+	RequirementInsecureAcceptAnything RequirementType = "insecureAcceptAnything"
+)
+
+// Requirement is a single entry of a policy.json scope's requirement list.
+type Requirement struct {
+	Type           RequirementType `json:"type"`
+	KeyPath        string          `json:"keyPath,omitempty"`
+	KeyData        string          `json:"keyData,omitempty"`
+	SignedIdentity string          `json:"signedIdentity,omitempty"`
+}
+
+// rawPolicy mirrors the on-disk shape of a containers/image policy.json file.
+type rawPolicy struct {
+	Default    []Requirement                       `json:"default"`
+	Transports map[string]map[string][]Requirement `json:"transports"`
+}
+
+// SignaturePolicy is a parsed, queryable containers/image policy.json,
+// scoped by transport (normally "docker") and then by registry/repo prefix.
+type SignaturePolicy struct {
+	defaultRequirements []Requirement
+	scoped              map[string][]Requirement
+}
+
+// LoadSignaturePolicy reads and parses a policy.json file in containers/image
+// signature/policy.json format, scoping requirements by "docker" transport
+// registry/repo entries. A path of "" returns a policy that accepts anything.
+func LoadSignaturePolicy(path string) (*SignaturePolicy, error) {
+	if path == "" {
+		return &SignaturePolicy{
+			defaultRequirements: []Requirement{{Type: RequirementInsecureAcceptAnything}},
+		}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading signature policy %s: %w", path, err)
+	}
+
+	var raw rawPolicy
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing signature policy %s: %w", path, err)
+	}
+
+	policy := &SignaturePolicy{
+		defaultRequirements: raw.Default,
+		scoped:              raw.Transports["docker"],
+	}
+	return policy, nil
+}
+
+// RequirementsFor returns the requirements that apply to ref, matching the
+// longest registry/repo scope configured under the "docker" transport and
+// falling back to the policy default.
+func (p *SignaturePolicy) RequirementsFor(ref string) []Requirement {
+	var best string
+	var bestReqs []Requirement
+	for scope, reqs := range p.scoped {
+		if strings.HasPrefix(ref, scope) && len(scope) > len(best) {
+			best = scope
+			bestReqs = reqs
+		}
+	}
+	if bestReqs != nil {
+		return bestReqs
+	}
+	return p.defaultRequirements
+}
+
+// Allows reports whether ref satisfies at least one of its requirements.
+// insecureAcceptAnything always allows; sigstoreSigned and signedBy are
+// satisfied only if verified reports a successful verification for ref -
+// actual cryptographic verification is delegated to the cosign/sigstore
+// client wired in by the caller.
+func (p *SignaturePolicy) Allows(ref string, verified bool) bool {
+	for _, req := range p.RequirementsFor(ref) {
+		switch req.Type {
+		case RequirementInsecureAcceptAnything:
+			return true
+		case RequirementSigstoreSigned, RequirementSignedBy:
+			if verified {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SignatureVerifier verifies the cosign signature(s) attached to ref using
+// trust material rooted via a Sigstore TUF mirror.
+type SignatureVerifier interface {
+	Verify(ctx context.Context, ref, signatureTag string) (bool, error)
+}
+
+// ArtifactCopier copies a single tagged OCI artifact (signature, attestation
+// or SBOM) from src to dst and checks whether a given tag exists on a
+// registry, the two primitives MirrorSignatures needs to discover and
+// replicate cosign's derived tags without depending on a specific transport.
+type ArtifactCopier interface {
+	CopyTag(ctx context.Context, src, dst, tag string) error
+	TagExists(ctx context.Context, ref, tag string) (bool, error)
+}
+
+// MirrorSignatures discovers the cosign signature, attestation and SBOM tags
+// derived from digest on src, verifies the signature tag against policy when
+// copying signatures, and copies the ones that exist to dst under the same
+// convention. Tags that do not exist on src are skipped silently.
+// continueOnError controls whether an error checking for a tag, a failed
+// verification or a failed copy aborts the whole operation or is only logged.
+func MirrorSignatures(ctx context.Context, copier ArtifactCopier, verifier SignatureVerifier, policy *SignaturePolicy, src, dst, digest string, copySignatures, copyAttestations, continueOnError bool) error {
+	tags := CosignTags(digest)
+	for i, tag := range tags {
+		isSignature := i == 0
+		if isSignature && !copySignatures {
+			continue
+		}
+		if !isSignature && !copyAttestations {
+			continue
+		}
+
+		exists, err := copier.TagExists(ctx, src, tag)
+		if err != nil {
+			if continueOnError {
+				klog.Warningf("checking for %s on %s: %v", tag, src, err)
+				continue
+			}
+			return fmt.Errorf("checking for %s on %s: %w", tag, src, err)
+		}
+		if !exists {
+			continue
+		}
+
+		if isSignature && policy != nil {
+			verified, err := verifier.Verify(ctx, src, tag)
+			if err != nil || !verified {
+				if !policy.Allows(src, verified) {
+					if continueOnError {
+						klog.Warningf("signature %s for %s did not satisfy policy, skipping", tag, src)
+						continue
+					}
+					return fmt.Errorf("signature %s for %s did not satisfy policy", tag, src)
+				}
+			}
+		}
+
+		if err := copier.CopyTag(ctx, src, dst, tag); err != nil {
+			if continueOnError {
+				klog.Warningf("copying %s from %s to %s: %v", tag, src, dst, err)
+				continue
+			}
+			return fmt.Errorf("copying %s from %s to %s: %w", tag, src, dst, err)
+		}
+		klog.Infof("mirrored %s for %s", tag, src)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCosignTags(t *testing.T) {
+	got := CosignTags("sha256:abcd1234")
+	want := []string{"sha256-abcd1234.sig", "sha256-abcd1234.att", "sha256-abcd1234.sbom"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("tag %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLoadSignaturePolicyEmptyPath(t *testing.T) {
+	policy, err := LoadSignaturePolicy("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !policy.Allows("registry.example.com/repo", false) {
+		t.Fatal("empty path policy should accept anything")
+	}
+}
+
+func TestLoadSignaturePolicyScoping(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.json")
+	policyJSON := `{
+		"default": [{"type": "insecureAcceptAnything"}],
+		"transports": {
+			"docker": {
+				"registry.example.com": [{"type": "sigstoreSigned"}],
+				"registry.example.com/trusted": [{"type": "signedBy", "keyPath": "/keys/trusted.pub"}]
+			}
+		}
+	}`
+	if err := os.WriteFile(path, []byte(policyJSON), 0600); err != nil {
+		t.Fatalf("writing policy file: %v", err)
+	}
+
+	policy, err := LoadSignaturePolicy(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("longest scope wins", func(t *testing.T) {
+		reqs := policy.RequirementsFor("registry.example.com/trusted/repo")
+		if len(reqs) != 1 || reqs[0].Type != RequirementSignedBy {
+			t.Fatalf("got %+v, want a single signedBy requirement", reqs)
+		}
+	})
+
+	t.Run("shorter scope applies outside the longer one", func(t *testing.T) {
+		reqs := policy.RequirementsFor("registry.example.com/other")
+		if len(reqs) != 1 || reqs[0].Type != RequirementSigstoreSigned {
+			t.Fatalf("got %+v, want a single sigstoreSigned requirement", reqs)
+		}
+	})
+
+	t.Run("default applies outside every scope", func(t *testing.T) {
+		reqs := policy.RequirementsFor("other-registry.example.com/repo")
+		if len(reqs) != 1 || reqs[0].Type != RequirementInsecureAcceptAnything {
+			t.Fatalf("got %+v, want the default requirement", reqs)
+		}
+	})
+
+	t.Run("sigstoreSigned requires verification", func(t *testing.T) {
+		ref := "registry.example.com/other"
+		if policy.Allows(ref, false) {
+			t.Fatal("expected unverified ref to be rejected")
+		}
+		if !policy.Allows(ref, true) {
+			t.Fatal("expected verified ref to be allowed")
+		}
+	})
+}
+
+// fakeArtifactCopier is an in-memory ArtifactCopier used to exercise
+// MirrorSignatures without a real registry.
+type fakeArtifactCopier struct {
+	existingTags map[string]bool
+	copiedTags   []string
+	copyErr      error
+}
+
+func (f *fakeArtifactCopier) TagExists(ctx context.Context, ref, tag string) (bool, error) {
+	return f.existingTags[tag], nil
+}
+
+func (f *fakeArtifactCopier) CopyTag(ctx context.Context, src, dst, tag string) error {
+	if f.copyErr != nil {
+		return f.copyErr
+	}
+	f.copiedTags = append(f.copiedTags, tag)
+	return nil
+}
+
+type fakeVerifier struct {
+	verified bool
+}
+
+func (f *fakeVerifier) Verify(ctx context.Context, ref, signatureTag string) (bool, error) {
+	return f.verified, nil
+}
+
+func TestMirrorSignaturesCopiesExistingTags(t *testing.T) {
+	digest := "sha256:abcd"
+	tags := CosignTags(digest)
+	copier := &fakeArtifactCopier{existingTags: map[string]bool{tags[0]: true, tags[1]: true}}
+	verifier := &fakeVerifier{verified: true}
+	policy, _ := LoadSignaturePolicy("")
+
+	err := MirrorSignatures(context.Background(), copier, verifier, policy, "src", "dst", digest, true, true, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(copier.copiedTags) != 2 || copier.copiedTags[0] != tags[0] || copier.copiedTags[1] != tags[1] {
+		t.Fatalf("got copied tags %v, want %v", copier.copiedTags, tags[:2])
+	}
+}
+
+func TestMirrorSignaturesSkipsWhenDisabled(t *testing.T) {
+	digest := "sha256:abcd"
+	tags := CosignTags(digest)
+	copier := &fakeArtifactCopier{existingTags: map[string]bool{tags[0]: true, tags[1]: true, tags[2]: true}}
+	verifier := &fakeVerifier{verified: true}
+	policy, _ := LoadSignaturePolicy("")
+
+	err := MirrorSignatures(context.Background(), copier, verifier, policy, "src", "dst", digest, false, false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(copier.copiedTags) != 0 {
+		t.Fatalf("expected no tags copied, got %v", copier.copiedTags)
+	}
+}
+
+func TestMirrorSignaturesContinueOnError(t *testing.T) {
+	digest := "sha256:abcd"
+	tags := CosignTags(digest)
+	copier := &fakeArtifactCopier{existingTags: map[string]bool{tags[0]: true}, copyErr: errors.New("registry unavailable")}
+	verifier := &fakeVerifier{verified: true}
+	policy, _ := LoadSignaturePolicy("")
+
+	if err := MirrorSignatures(context.Background(), copier, verifier, policy, "src", "dst", digest, true, false, false); err == nil {
+		t.Fatal("expected error without --continue-on-error")
+	}
+	if err := MirrorSignatures(context.Background(), copier, verifier, policy, "src", "dst", digest, true, false, true); err != nil {
+		t.Fatalf("expected --continue-on-error to swallow the copy failure, got %v", err)
+	}
+}